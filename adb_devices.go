@@ -0,0 +1,72 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// adbDeviceInfo holds the fields that 'adb devices -l' reports for a single
+// attached device, beyond its serial.
+type adbDeviceInfo struct {
+	Serial  string
+	Product string
+	Model   string
+	Device  string
+}
+
+// listAdbDevices runs 'adb devices -l' and parses its output. It is the
+// single place that shells out to adb for device listing; both 'madb name
+// auto' and any future callers that need the full device info should go
+// through this helper rather than re-invoking and re-parsing adb themselves.
+func listAdbDevices() ([]adbDeviceInfo, error) {
+	out, err := exec.Command("adb", "devices", "-l").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not run 'adb devices -l': %v", err)
+	}
+
+	return parseAdbDevicesL(string(out)), nil
+}
+
+// parseAdbDevicesL parses the output of 'adb devices -l', e.g.:
+//
+//	List of devices attached
+//	HT4BVWV00023           device usb:3-3.4.2 product:volantisg model:Nexus_9 device:flounder_lte
+//
+// Lines that do not look like a device entry (e.g., the leading banner, or
+// devices that are not in the "device" state) are skipped.
+func parseAdbDevicesL(output string) []adbDeviceInfo {
+	var devices []adbDeviceInfo
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "device" {
+			continue
+		}
+
+		info := adbDeviceInfo{Serial: fields[0]}
+		for _, field := range fields[2:] {
+			kv := strings.SplitN(field, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+
+			switch kv[0] {
+			case "product":
+				info.Product = kv[1]
+			case "model":
+				info.Model = kv[1]
+			case "device":
+				info.Device = kv[1]
+			}
+		}
+
+		devices = append(devices, info)
+	}
+
+	return devices
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"v.io/x/lib/cmdline"
+)
+
+// config stores all the persistent, user-configurable state used by madb,
+// such as the device nicknames set up via the 'madb name' command.
+type config struct {
+	// Names is a one-to-one mapping between nicknames and device serials.
+	Names map[string]string
+
+	// Groups is a many-to-many mapping between group tags and the set of
+	// device serials or nicknames that belong to that group.
+	Groups map[string][]string
+}
+
+// newConfig returns an empty, fully initialized config.
+func newConfig() *config {
+	return &config{
+		Names:  make(map[string]string),
+		Groups: make(map[string][]string),
+	}
+}
+
+// getDefaultConfigFilePath returns the path to the config file used by madb
+// when no other path is specified on the command line.
+func getDefaultConfigFilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine the home directory: %v", err)
+	}
+
+	return filepath.Join(homeDir, ".madb"), nil
+}
+
+// readConfig reads and decodes the config stored in the given file.
+// If the file does not exist, an empty config is returned.
+func readConfig(filename string) (*config, error) {
+	cfg := newConfig()
+
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("could not open the config file %q: %v", filename, err)
+	}
+	defer file.Close()
+
+	if err := gob.NewDecoder(file).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("could not decode the config file %q: %v", filename, err)
+	}
+
+	// The config file may have been written before the Groups field existed.
+	// Migrate it in place so the rest of madb can assume it is non-nil.
+	if cfg.Names == nil {
+		cfg.Names = make(map[string]string)
+	}
+	if cfg.Groups == nil {
+		cfg.Groups = make(map[string][]string)
+	}
+
+	return cfg, nil
+}
+
+// writeConfig encodes the given config and writes it to the given file.
+func writeConfig(cfg *config, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("could not create the config file %q: %v", filename, err)
+	}
+	defer file.Close()
+
+	if err := gob.NewEncoder(file).Encode(cfg); err != nil {
+		return fmt.Errorf("could not encode the config file %q: %v", filename, err)
+	}
+
+	return nil
+}
+
+var (
+	validSerialRE = regexp.MustCompile(`^[a-zA-Z0-9:._-]+$`)
+	validNameRE   = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// isValidSerial returns true if the given string can be used as a device
+// serial or an alternative device qualifier (e.g., 'usb:3-3.4.2').
+func isValidSerial(serial string) bool {
+	return validSerialRE.MatchString(serial)
+}
+
+// isValidName returns true if the given string can be used as a nickname or
+// a group tag: an alpha-numeric string, optionally with underscores or
+// hyphens, and no spaces (e.g., 'Nexus_9-HT4B').
+func isValidName(name string) bool {
+	return validNameRE.MatchString(name)
+}
+
+// isNameInUse returns true if the given nickname is already assigned to a
+// device serial in the config.
+func isNameInUse(name string, cfg *config) bool {
+	_, present := cfg.Names[name]
+	return present
+}
+
+// byFirstElement implements sort.Interface for [][]string, ordering rows by
+// their first column.
+type byFirstElement [][]string
+
+func (s byFirstElement) Len() int           { return len(s) }
+func (s byFirstElement) Less(i, j int) bool { return s[i][0] < s[j][0] }
+func (s byFirstElement) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// subCommandRunnerWithFilepath adapts a runner function that takes an
+// explicit config file path to the cmdline.Runner interface. The path passed
+// to the runner is always the one returned by defaultPath.
+type subCommandRunnerWithFilepath struct {
+	runner      func(env *cmdline.Env, args []string, filename string) error
+	defaultPath func() (string, error)
+}
+
+func (r subCommandRunnerWithFilepath) Run(env *cmdline.Env, args []string) error {
+	filename, err := r.defaultPath()
+	if err != nil {
+		return err
+	}
+	return r.runner(env, args, filename)
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+
+	"v.io/x/lib/cmdline"
+)
+
+var cmdMadbGroup = &cmdline.Command{
+	Children:         []*cmdline.Command{cmdMadbGroupAdd, cmdMadbGroupRemove, cmdMadbGroupList},
+	Name:             "group",
+	DontInheritFlags: true,
+	Short:            "Manage device groups",
+	Long: `
+Manages device groups, which let a single tag refer to many devices at once.
+
+Unlike nicknames, which are a one-to-one mapping between a name and a device
+serial, a group is a many-to-many tag: a device can belong to any number of
+groups, and a group can contain any number of devices. 'madb name unset' also
+accepts '@<group>' to unset the nickname of every device currently in that
+group; the 'madb group' subcommands do not expand '@<group>' references.
+`,
+}
+
+var cmdMadbGroupAdd = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbGroupAdd, getDefaultConfigFilePath},
+	Name:   "add",
+	Short:  "Add devices to a group.",
+	Long: `
+Adds one or more devices to a group, creating the group if it does not
+already exist.
+`,
+	ArgsName: "<group> <device_serial_or_nickname>...",
+	ArgsLong: `
+<group> is the group tag (e.g., 'tablets').
+<device_serial_or_nickname> is a device serial, an alternative device
+qualifier, or a nickname assigned via 'madb name set'.
+`,
+}
+
+func runMadbGroupAdd(env *cmdline.Env, args []string, filename string) error {
+	if len(args) < 2 {
+		return env.UsageErrorf("There must be at least two arguments.")
+	}
+
+	group, members := args[0], args[1:]
+	if !isValidName(group) {
+		return env.UsageErrorf("Not a valid group name: %v", group)
+	}
+
+	for _, member := range members {
+		if !isValidSerial(member) && !isValidName(member) {
+			return env.UsageErrorf("Not a valid device serial or nickname: %v", member)
+		}
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(cfg.Groups[group]))
+	for _, member := range cfg.Groups[group] {
+		existing[member] = true
+	}
+
+	for _, member := range members {
+		if !existing[member] {
+			cfg.Groups[group] = append(cfg.Groups[group], member)
+			existing[member] = true
+		}
+	}
+	sort.Strings(cfg.Groups[group])
+
+	return writeConfig(cfg, filename)
+}
+
+var cmdMadbGroupRemove = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbGroupRemove, getDefaultConfigFilePath},
+	Name:   "remove",
+	Short:  "Remove devices from a group.",
+	Long: `
+Removes one or more devices from a group. If the group ends up empty, it is
+deleted.
+`,
+	ArgsName: "<group> <device_serial_or_nickname>...",
+	ArgsLong: `
+<group> is the group tag (e.g., 'tablets').
+<device_serial_or_nickname> is a device serial, an alternative device
+qualifier, or a nickname assigned via 'madb name set'.
+`,
+}
+
+func runMadbGroupRemove(env *cmdline.Env, args []string, filename string) error {
+	if len(args) < 2 {
+		return env.UsageErrorf("There must be at least two arguments.")
+	}
+
+	group, members := args[0], args[1:]
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, present := cfg.Groups[group]; !present {
+		return fmt.Errorf("The provided group %q does not exist.", group)
+	}
+
+	toRemove := make(map[string]bool, len(members))
+	for _, member := range members {
+		toRemove[member] = true
+	}
+
+	remaining := cfg.Groups[group][:0]
+	for _, member := range cfg.Groups[group] {
+		if !toRemove[member] {
+			remaining = append(remaining, member)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(cfg.Groups, group)
+	} else {
+		cfg.Groups[group] = remaining
+	}
+
+	return writeConfig(cfg, filename)
+}
+
+var cmdMadbGroupList = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbGroupList, getDefaultConfigFilePath},
+	Name:   "list",
+	Short:  "List all the existing groups.",
+	Long: `
+Lists all the currently stored groups and their member devices.
+`,
+}
+
+func runMadbGroupList(env *cmdline.Env, args []string, filename string) error {
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Group", "Members"})
+	tw.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	tw.SetAutoFormatHeaders(false)
+	tw.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	data := make([][]string, 0, len(cfg.Groups))
+	for group, members := range cfg.Groups {
+		sorted := append([]string(nil), members...)
+		sort.Strings(sorted)
+		data = append(data, []string{group, strings.Join(sorted, ", ")})
+	}
+
+	sort.Sort(byFirstElement(data))
+
+	for _, row := range data {
+		tw.Append(row)
+	}
+	tw.Render()
+
+	return nil
+}
+
+// resolveDeviceRef expands a device reference into the list of device
+// serials or nicknames it refers to. A reference of the form '@<group>'
+// expands to that group's members; any other reference is returned
+// unchanged. 'madb name unset' calls this first so that it also accepts
+// '@<group>'.
+func resolveDeviceRef(cfg *config, ref string) ([]string, error) {
+	if !strings.HasPrefix(ref, "@") {
+		return []string{ref}, nil
+	}
+
+	group := strings.TrimPrefix(ref, "@")
+	members, present := cfg.Groups[group]
+	if !present {
+		return nil, fmt.Errorf("The provided group %q does not exist.", group)
+	}
+
+	return members, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveDeviceRefNotAGroup(t *testing.T) {
+	cfg := newConfig()
+
+	got, err := resolveDeviceRef(cfg, "HT4BVWV00023")
+	if err != nil {
+		t.Fatalf("resolveDeviceRef() failed: %v", err)
+	}
+	if want := []string{"HT4BVWV00023"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveDeviceRef() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveDeviceRefExpandsGroup(t *testing.T) {
+	cfg := newConfig()
+	cfg.Groups["tablets"] = []string{"HT4BVWV00023", "MyTablet"}
+
+	got, err := resolveDeviceRef(cfg, "@tablets")
+	if err != nil {
+		t.Fatalf("resolveDeviceRef() failed: %v", err)
+	}
+	if want := []string{"HT4BVWV00023", "MyTablet"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveDeviceRef() = %v, want %v", got, want)
+	}
+}
+
+func TestResolveDeviceRefUnknownGroup(t *testing.T) {
+	cfg := newConfig()
+
+	if _, err := resolveDeviceRef(cfg, "@tablets"); err == nil {
+		t.Errorf("resolveDeviceRef() succeeded, want error for an unknown group")
+	}
+}
+
+func TestRunMadbGroupAddDedups(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config")
+
+	if err := runMadbGroupAdd(nil, []string{"tablets", "HT4BVWV00023", "MyTablet"}, filename); err != nil {
+		t.Fatalf("runMadbGroupAdd() failed: %v", err)
+	}
+	// Adding an overlapping set of members must not duplicate entries.
+	if err := runMadbGroupAdd(nil, []string{"tablets", "MyTablet", "OtherDevice"}, filename); err != nil {
+		t.Fatalf("runMadbGroupAdd() failed: %v", err)
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		t.Fatalf("readConfig() failed: %v", err)
+	}
+
+	want := []string{"HT4BVWV00023", "MyTablet", "OtherDevice"}
+	if got := cfg.Groups["tablets"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("cfg.Groups[%q] = %v, want %v", "tablets", got, want)
+	}
+}
+
+func TestRunMadbGroupRemove(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config")
+
+	if err := runMadbGroupAdd(nil, []string{"tablets", "HT4BVWV00023", "MyTablet"}, filename); err != nil {
+		t.Fatalf("runMadbGroupAdd() failed: %v", err)
+	}
+	if err := runMadbGroupRemove(nil, []string{"tablets", "MyTablet"}, filename); err != nil {
+		t.Fatalf("runMadbGroupRemove() failed: %v", err)
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		t.Fatalf("readConfig() failed: %v", err)
+	}
+
+	want := []string{"HT4BVWV00023"}
+	if got := cfg.Groups["tablets"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("cfg.Groups[%q] = %v, want %v", "tablets", got, want)
+	}
+
+	// Removing the last member should delete the group entirely.
+	if err := runMadbGroupRemove(nil, []string{"tablets", "HT4BVWV00023"}, filename); err != nil {
+		t.Fatalf("runMadbGroupRemove() failed: %v", err)
+	}
+
+	cfg, err = readConfig(filename)
+	if err != nil {
+		t.Fatalf("readConfig() failed: %v", err)
+	}
+	if _, present := cfg.Groups["tablets"]; present {
+		t.Errorf("cfg.Groups[%q] still present, want it deleted once empty", "tablets")
+	}
+}
+
+func TestRunMadbGroupRemoveUnknownGroup(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config")
+
+	if err := runMadbGroupRemove(nil, []string{"tablets", "HT4BVWV00023"}, filename); err == nil {
+		t.Errorf("runMadbGroupRemove() succeeded, want error for an unknown group")
+	}
+}
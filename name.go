@@ -5,9 +5,12 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/olekukonko/tablewriter"
 
@@ -15,7 +18,7 @@ import (
 )
 
 var cmdMadbName = &cmdline.Command{
-	Children:         []*cmdline.Command{cmdMadbNameSet, cmdMadbNameUnset, cmdMadbNameList, cmdMadbNameClearAll},
+	Children:         []*cmdline.Command{cmdMadbNameSet, cmdMadbNameUnset, cmdMadbNameAuto, cmdMadbNameList, cmdMadbNameClearAll, cmdMadbNameExport, cmdMadbNameImport},
 	Name:             "name",
 	DontInheritFlags: true,
 	Short:            "Manage device nicknames",
@@ -81,6 +84,22 @@ func runMadbNameSet(env *cmdline.Env, args []string, filename string) error {
 		return err
 	}
 
+	if err := setName(cfg, serial, nickname); err != nil {
+		return err
+	}
+
+	return writeConfig(cfg, filename)
+}
+
+// setName assigns the given nickname to the given device serial in cfg.
+// It is the non-CLI counterpart of 'madb name set', factored out so that
+// other commands (e.g., 'madb name auto') can assign many nicknames in a
+// loop without going through the cmdline.Runner machinery.
+func setName(cfg *config, serial, nickname string) error {
+	if !isValidName(nickname) {
+		return fmt.Errorf("Not a valid nickname: %v", nickname)
+	}
+
 	// If the nickname is already in use, don't allow it at all.
 	if isNameInUse(nickname, cfg) {
 		return fmt.Errorf("The provided nickname %q is already in use.", nickname)
@@ -95,7 +114,7 @@ func runMadbNameSet(env *cmdline.Env, args []string, filename string) error {
 	// Add the nickname serial mapping.
 	cfg.Names[nickname] = serial
 
-	return writeConfig(cfg, filename)
+	return nil
 }
 
 var cmdMadbNameUnset = &cmdline.Command{
@@ -103,12 +122,14 @@ var cmdMadbNameUnset = &cmdline.Command{
 	Name:   "unset",
 	Short:  "Unset a nickname set by the 'madb name set' command.",
 	Long: `
-Unsets a nickname assigned by the 'madb name set' command. Either the device
-serial or the assigned nickname can be specified to remove the mapping.
+Unsets a nickname assigned by the 'madb name set' command. The device serial,
+the assigned nickname, or a '@<group>' reference can be specified; in the
+'@<group>' case, the nickname of every member of the group is unset.
 `,
-	ArgsName: "<device_serial | nickname>",
+	ArgsName: "<device_serial | nickname | @group>",
 	ArgsLong: `
-There should be only one argument, which is either the device serial or the nickname.
+There should be only one argument, which is either the device serial, the
+nickname, or a '@<group>' reference.
 `,
 }
 
@@ -119,7 +140,11 @@ func runMadbNameUnset(env *cmdline.Env, args []string, filename string) error {
 	}
 
 	name := args[0]
-	if !isValidSerial(name) && !isValidName(name) {
+	if group := strings.TrimPrefix(name, "@"); group != name {
+		if !isValidName(group) {
+			return env.UsageErrorf("Not a valid group: %v", name)
+		}
+	} else if !isValidSerial(name) && !isValidName(name) {
 		return env.UsageErrorf("Not a valid device serial or name: %v", name)
 	}
 
@@ -128,22 +153,172 @@ func runMadbNameUnset(env *cmdline.Env, args []string, filename string) error {
 		return err
 	}
 
+	targets, err := resolveDeviceRef(cfg, name)
+	if err != nil {
+		return err
+	}
+
 	found := false
-	for nickname, serial := range cfg.Names {
-		if nickname == name || serial == name {
-			delete(cfg.Names, nickname)
-			found = true
-			break
+	for _, target := range targets {
+		for nickname, serial := range cfg.Names {
+			if nickname == target || serial == target {
+				delete(cfg.Names, nickname)
+				found = true
+				break
+			}
 		}
 	}
 
 	if !found {
-		return fmt.Errorf("The provided argument is neither a known nickname nor a device serial.")
+		return fmt.Errorf("The provided argument is neither a known nickname, device serial, nor group.")
+	}
+
+	return writeConfig(cfg, filename)
+}
+
+var cmdMadbNameAuto = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbNameAuto, getDefaultConfigFilePath},
+	Name:   "auto",
+	Short:  "Automatically assign nicknames based on 'adb devices -l' properties.",
+	Long: `
+Runs 'adb devices -l' and proposes a nickname for every attached device that
+does not already have one, based on the 'product', 'model', and 'device'
+fields adb reports. The default naming scheme is '<model>-<serial prefix>'
+(e.g., 'Nexus_9-HT4B' for a device reporting 'model:Nexus_9' with serial
+'HT4BVWV00023'); use -template to customize it.
+
+If the generated nickname collides with one already in use, it is
+disambiguated by growing the serial prefix until it is unique.
+`,
+}
+
+const defaultNameAutoTemplate = "{{.Model}}-{{.SerialPrefix}}"
+
+var (
+	flagNameAutoTemplate  string
+	flagNameAutoDryRun    bool
+	flagNameAutoOverwrite bool
+)
+
+func init() {
+	cmdMadbNameAuto.Flags.StringVar(&flagNameAutoTemplate, "template", defaultNameAutoTemplate, "Go text/template used to generate the nickname. The fields available are .Serial, .SerialPrefix, .Product, .Model, and .Device.")
+	cmdMadbNameAuto.Flags.BoolVar(&flagNameAutoDryRun, "dry-run", false, "Print the nicknames that would be assigned without actually writing them.")
+	cmdMadbNameAuto.Flags.BoolVar(&flagNameAutoOverwrite, "overwrite", false, "Reassign a nickname even for devices that already have one.")
+}
+
+// nameAutoData is the data passed to the -template flag's text/template for
+// each attached device.
+type nameAutoData struct {
+	Serial       string
+	SerialPrefix string
+	Product      string
+	Model        string
+	Device       string
+}
+
+func runMadbNameAuto(env *cmdline.Env, args []string, filename string) error {
+	if len(args) != 0 {
+		return env.UsageErrorf("There must be no arguments.")
+	}
+
+	tmpl, err := template.New("madb-name-auto").Parse(flagNameAutoTemplate)
+	if err != nil {
+		return fmt.Errorf("Not a valid -template value: %v", err)
+	}
+
+	devices, err := listAdbDevices()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	reverse := reverseMap(cfg.Names)
+
+	tw := tablewriter.NewWriter(os.Stdout)
+	tw.SetHeader([]string{"Serial", "Nickname"})
+	tw.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	tw.SetAutoFormatHeaders(false)
+	tw.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	assigned := false
+	for _, device := range devices {
+		if _, present := reverse[device.Serial]; present && !flagNameAutoOverwrite {
+			continue
+		}
+
+		nickname, err := proposeName(tmpl, device, cfg)
+		if err != nil {
+			return err
+		}
+
+		tw.Append([]string{device.Serial, nickname})
+
+		// Apply the assignment to cfg even in -dry-run, so that later devices
+		// in this same run see it via proposeName's isNameInUse check and are
+		// disambiguated the same way a real run would disambiguate them. Only
+		// the final writeConfig is skipped for -dry-run.
+		if name, present := reverse[device.Serial]; present {
+			delete(cfg.Names, name)
+		}
+
+		if err := setName(cfg, device.Serial, nickname); err != nil {
+			return err
+		}
+		assigned = true
+	}
+
+	tw.Render()
+
+	if flagNameAutoDryRun || !assigned {
+		return nil
 	}
 
 	return writeConfig(cfg, filename)
 }
 
+// proposeName executes tmpl against the given device, falling back to a
+// growing serial prefix to resolve collisions with names already in cfg.
+func proposeName(tmpl *template.Template, device adbDeviceInfo, cfg *config) (string, error) {
+	prefixLen := 4
+	for {
+		data := nameAutoData{
+			Serial:       device.Serial,
+			SerialPrefix: serialPrefix(device.Serial, prefixLen),
+			Product:      device.Product,
+			Model:        device.Model,
+			Device:       device.Device,
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return "", fmt.Errorf("could not execute -template: %v", err)
+		}
+		nickname := buf.String()
+
+		if !isNameInUse(nickname, cfg) {
+			return nickname, nil
+		}
+
+		if prefixLen >= len(device.Serial) {
+			return "", fmt.Errorf("could not generate a unique nickname for device %q", device.Serial)
+		}
+		prefixLen++
+	}
+}
+
+// serialPrefix returns the first n characters of serial, or the whole serial
+// if it is shorter than n.
+func serialPrefix(serial string, n int) string {
+	if n >= len(serial) {
+		return serial
+	}
+	return serial[:n]
+}
+
 var cmdMadbNameList = &cmdline.Command{
 	Runner: subCommandRunnerWithFilepath{runMadbNameList, getDefaultConfigFilePath},
 	Name:   "list",
@@ -0,0 +1,252 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"v.io/x/lib/cmdline"
+)
+
+// nameFileVersion is the schema version written to the header of exported
+// YAML documents. It is bumped whenever the document's shape changes in a
+// way that importers need to know about.
+const nameFileVersion = 1
+
+// nameFile is the portable, human-editable representation of the parts of
+// the config that 'madb name export'/'madb name import' round-trip.
+type nameFile struct {
+	Version int                 `yaml:"version"`
+	Names   map[string]string   `yaml:"names,omitempty"`
+	Groups  map[string][]string `yaml:"groups,omitempty"`
+}
+
+var cmdMadbNameExport = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbNameExport, getDefaultConfigFilePath},
+	Name:   "export",
+	Short:  "Export nicknames and groups as a portable YAML document.",
+	Long: `
+Exports the current nicknames and groups as a YAML document with a schema
+version header, suitable for checking into a shared repository and syncing
+across workstations with 'madb name import'.
+`,
+}
+
+var flagNameExportOutput string
+
+func init() {
+	cmdMadbNameExport.Flags.StringVar(&flagNameExportOutput, "o", "", "File to write the YAML document to. Defaults to stdout.")
+}
+
+func runMadbNameExport(env *cmdline.Env, args []string, filename string) error {
+	if len(args) != 0 {
+		return env.UsageErrorf("There must be no arguments.")
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(nameFile{
+		Version: nameFileVersion,
+		Names:   cfg.Names,
+		Groups:  cfg.Groups,
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal the nickname config: %v", err)
+	}
+
+	if flagNameExportOutput == "" {
+		_, err = env.Stdout.Write(out)
+		return err
+	}
+
+	return ioutil.WriteFile(flagNameExportOutput, out, 0644)
+}
+
+var cmdMadbNameImport = &cmdline.Command{
+	Runner: subCommandRunnerWithFilepath{runMadbNameImport, getDefaultConfigFilePath},
+	Name:   "import",
+	Short:  "Import nicknames and groups from a portable YAML document.",
+	Long: `
+Imports nicknames and groups from a YAML document produced by 'madb name
+export'. Every entry is validated the same way 'madb name set' and 'madb
+group add' validate their arguments.
+
+Unless -replace is passed, group membership is merged into the existing
+groups, since it is purely additive: it only ever adds devices to a group,
+never removes any.
+
+Nicknames are also merged by default, but only if doing so is unambiguous:
+an imported nickname that is new, or that already maps to the same serial,
+is added with no extra flags required. If an imported nickname already maps
+to a different serial, import refuses to run so it doesn't silently
+clobber the existing mapping; pass -merge with -on-conflict to say how such
+conflicts should be resolved ("skip" the import's value, "overwrite" the
+existing one, or "error" out), or pass -replace to discard all existing
+nicknames and groups before importing.
+`,
+	ArgsName: "<file>",
+	ArgsLong: `
+<file> is the path to a YAML document produced by 'madb name export'.
+`,
+}
+
+var (
+	flagNameImportMerge      bool
+	flagNameImportReplace    bool
+	flagNameImportOnConflict string
+)
+
+func init() {
+	cmdMadbNameImport.Flags.BoolVar(&flagNameImportMerge, "merge", false, "Merge the imported entries into the existing config.")
+	cmdMadbNameImport.Flags.BoolVar(&flagNameImportReplace, "replace", false, "Discard the existing nicknames and groups before importing.")
+	cmdMadbNameImport.Flags.StringVar(&flagNameImportOnConflict, "on-conflict", "skip", "How to resolve a nickname that already maps to a different serial when -merge is passed: \"skip\", \"overwrite\", or \"error\".")
+}
+
+func runMadbNameImport(env *cmdline.Env, args []string, filename string) error {
+	if len(args) != 1 {
+		return env.UsageErrorf("There must be exactly one argument.")
+	}
+
+	if flagNameImportMerge && flagNameImportReplace {
+		return env.UsageErrorf("-merge and -replace cannot be used together.")
+	}
+
+	switch flagNameImportOnConflict {
+	case "skip", "overwrite", "error":
+	default:
+		return env.UsageErrorf("Not a valid -on-conflict value: %v", flagNameImportOnConflict)
+	}
+
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("could not read %q: %v", args[0], err)
+	}
+
+	var nf nameFile
+	if err := yaml.Unmarshal(data, &nf); err != nil {
+		return fmt.Errorf("could not parse %q: %v", args[0], err)
+	}
+
+	if nf.Version != nameFileVersion {
+		return fmt.Errorf("%q has schema version %d, but this version of madb only understands version %d", args[0], nf.Version, nameFileVersion)
+	}
+
+	for nickname, serial := range nf.Names {
+		if !isValidName(nickname) {
+			return fmt.Errorf("%q contains an invalid nickname: %v", args[0], nickname)
+		}
+		if !isValidSerial(serial) {
+			return fmt.Errorf("%q contains an invalid device serial: %v", args[0], serial)
+		}
+	}
+
+	for group, members := range nf.Groups {
+		if !isValidName(group) {
+			return fmt.Errorf("%q contains an invalid group name: %v", args[0], group)
+		}
+		for _, member := range members {
+			if !isValidSerial(member) && !isValidName(member) {
+				return fmt.Errorf("%q contains an invalid group member: %v", args[0], member)
+			}
+		}
+	}
+
+	cfg, err := readConfig(filename)
+	if err != nil {
+		return err
+	}
+
+	if !flagNameImportMerge && !flagNameImportReplace {
+		if nickname, serial, existing := firstNameConflict(cfg, nf); nickname != "" {
+			return fmt.Errorf("nickname %q already maps to %q, but the import wants to map it to %q; rerun with -merge or -replace", nickname, existing, serial)
+		}
+	}
+
+	if flagNameImportReplace {
+		cfg.Names = make(map[string]string)
+		cfg.Groups = make(map[string][]string)
+	}
+
+	summary, err := mergeNameFile(cfg, nf, flagNameImportOnConflict)
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(summary)
+	for _, line := range summary {
+		fmt.Fprintln(env.Stdout, line)
+	}
+
+	return writeConfig(cfg, filename)
+}
+
+// firstNameConflict returns the first nickname in nf that is already present
+// in cfg.Names with a different serial, along with the two conflicting
+// serials. It returns an empty nickname if there is no such conflict; a
+// nickname that already maps to the same serial is not a conflict.
+func firstNameConflict(cfg *config, nf nameFile) (nickname, serial, existing string) {
+	for nickname, serial := range nf.Names {
+		if existing, present := cfg.Names[nickname]; present && existing != serial {
+			return nickname, serial, existing
+		}
+	}
+	return "", "", ""
+}
+
+// mergeNameFile applies the entries in nf to cfg according to onConflict,
+// and returns a diff-style summary of what changed.
+func mergeNameFile(cfg *config, nf nameFile, onConflict string) ([]string, error) {
+	var summary []string
+
+	for nickname, serial := range nf.Names {
+		existing, present := cfg.Names[nickname]
+		switch {
+		case !present:
+			cfg.Names[nickname] = serial
+			summary = append(summary, fmt.Sprintf("+ %s -> %s", nickname, serial))
+		case existing == serial:
+			// Nothing to do; already up to date.
+		default:
+			switch onConflict {
+			case "skip":
+				summary = append(summary, fmt.Sprintf("! %s -> %s (kept %s)", nickname, serial, existing))
+			case "overwrite":
+				cfg.Names[nickname] = serial
+				summary = append(summary, fmt.Sprintf("~ %s -> %s (was %s)", nickname, serial, existing))
+			case "error":
+				return nil, fmt.Errorf("nickname %q already maps to %q, but the import wants to map it to %q", nickname, existing, serial)
+			}
+		}
+	}
+
+	for group, members := range nf.Groups {
+		existing := make(map[string]bool, len(cfg.Groups[group]))
+		for _, member := range cfg.Groups[group] {
+			existing[member] = true
+		}
+
+		added := false
+		for _, member := range members {
+			if !existing[member] {
+				cfg.Groups[group] = append(cfg.Groups[group], member)
+				existing[member] = true
+				added = true
+			}
+		}
+		if added {
+			sort.Strings(cfg.Groups[group])
+			summary = append(summary, fmt.Sprintf("+ group %s", group))
+		}
+	}
+
+	return summary, nil
+}
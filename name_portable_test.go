@@ -0,0 +1,94 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestMergeNameFileNewEntries(t *testing.T) {
+	cfg := newConfig()
+	nf := nameFile{
+		Version: nameFileVersion,
+		Names:   map[string]string{"MyTablet": "HT4BVWV00023"},
+		Groups:  map[string][]string{"tablets": {"MyTablet"}},
+	}
+
+	if _, err := mergeNameFile(cfg, nf, "skip"); err != nil {
+		t.Fatalf("mergeNameFile() failed: %v", err)
+	}
+
+	if got, want := cfg.Names["MyTablet"], "HT4BVWV00023"; got != want {
+		t.Errorf("cfg.Names[%q] = %q, want %q", "MyTablet", got, want)
+	}
+	if got, want := len(cfg.Groups["tablets"]), 1; got != want {
+		t.Errorf("len(cfg.Groups[%q]) = %d, want %d", "tablets", got, want)
+	}
+}
+
+func TestMergeNameFileConflictSkip(t *testing.T) {
+	cfg := newConfig()
+	cfg.Names["MyTablet"] = "HT4BVWV00023"
+	nf := nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "OTHERSERIAL"}}
+
+	if _, err := mergeNameFile(cfg, nf, "skip"); err != nil {
+		t.Fatalf("mergeNameFile() failed: %v", err)
+	}
+
+	if got, want := cfg.Names["MyTablet"], "HT4BVWV00023"; got != want {
+		t.Errorf("cfg.Names[%q] = %q, want %q (skip should keep the existing value)", "MyTablet", got, want)
+	}
+}
+
+func TestMergeNameFileConflictOverwrite(t *testing.T) {
+	cfg := newConfig()
+	cfg.Names["MyTablet"] = "HT4BVWV00023"
+	nf := nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "OTHERSERIAL"}}
+
+	if _, err := mergeNameFile(cfg, nf, "overwrite"); err != nil {
+		t.Fatalf("mergeNameFile() failed: %v", err)
+	}
+
+	if got, want := cfg.Names["MyTablet"], "OTHERSERIAL"; got != want {
+		t.Errorf("cfg.Names[%q] = %q, want %q (overwrite should take the imported value)", "MyTablet", got, want)
+	}
+}
+
+func TestMergeNameFileConflictError(t *testing.T) {
+	cfg := newConfig()
+	cfg.Names["MyTablet"] = "HT4BVWV00023"
+	nf := nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "OTHERSERIAL"}}
+
+	if _, err := mergeNameFile(cfg, nf, "error"); err == nil {
+		t.Errorf("mergeNameFile() succeeded, want error because of the conflicting nickname")
+	}
+}
+
+func TestMergeNameFileSameValueIsNotAConflict(t *testing.T) {
+	cfg := newConfig()
+	cfg.Names["MyTablet"] = "HT4BVWV00023"
+	nf := nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "HT4BVWV00023"}}
+
+	summary, err := mergeNameFile(cfg, nf, "error")
+	if err != nil {
+		t.Fatalf("mergeNameFile() failed: %v", err)
+	}
+	if len(summary) != 0 {
+		t.Errorf("mergeNameFile() summary = %v, want empty since the entry was already up to date", summary)
+	}
+}
+
+func TestFirstNameConflict(t *testing.T) {
+	cfg := newConfig()
+	cfg.Names["MyTablet"] = "HT4BVWV00023"
+
+	nf := nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "HT4BVWV00023"}}
+	if nickname, _, _ := firstNameConflict(cfg, nf); nickname != "" {
+		t.Errorf("firstNameConflict() = %q, want no conflict for an identical re-import", nickname)
+	}
+
+	nf = nameFile{Version: nameFileVersion, Names: map[string]string{"MyTablet": "OTHERSERIAL"}}
+	if nickname, _, _ := firstNameConflict(cfg, nf); nickname != "MyTablet" {
+		t.Errorf("firstNameConflict() = %q, want %q", nickname, "MyTablet")
+	}
+}
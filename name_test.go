@@ -0,0 +1,58 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"text/template"
+)
+
+func TestProposeName(t *testing.T) {
+	tmpl, err := template.New("test").Parse(defaultNameAutoTemplate)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	cfg := newConfig()
+
+	device1 := adbDeviceInfo{Serial: "HT4BVWV00023", Model: "Nexus_9"}
+	name1, err := proposeName(tmpl, device1, cfg)
+	if err != nil {
+		t.Fatalf("proposeName(%+v) failed: %v", device1, err)
+	}
+	if want := "Nexus_9-HT4B"; name1 != want {
+		t.Errorf("proposeName(%+v) = %q, want %q", device1, name1, want)
+	}
+	cfg.Names[name1] = device1.Serial
+
+	// A second device with the same model and a serial that shares the same
+	// 4-character prefix must be disambiguated by growing the prefix.
+	device2 := adbDeviceInfo{Serial: "HT4BVWV00099", Model: "Nexus_9"}
+	name2, err := proposeName(tmpl, device2, cfg)
+	if err != nil {
+		t.Fatalf("proposeName(%+v) failed: %v", device2, err)
+	}
+	if name2 == name1 {
+		t.Errorf("proposeName(%+v) = %q, want a name distinct from %q", device2, name2, name1)
+	}
+	if want := "Nexus_9-HT4BV"; name2 != want {
+		t.Errorf("proposeName(%+v) = %q, want %q", device2, name2, want)
+	}
+}
+
+func TestProposeNameExhausted(t *testing.T) {
+	tmpl, err := template.New("test").Parse(defaultNameAutoTemplate)
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+
+	cfg := newConfig()
+	device := adbDeviceInfo{Serial: "HT4B", Model: "Nexus_9"}
+	cfg.Names["Nexus_9-HT4B"] = device.Serial
+
+	if _, err := proposeName(tmpl, device, cfg); err == nil {
+		t.Errorf("proposeName(%+v) succeeded, want error because the serial is fully exhausted", device)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2016 The Vanadium Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"v.io/x/lib/cmdline"
+)
+
+// cmdMadb is the root command of the madb tool. Every top-level subcommand
+// (e.g., 'madb name', 'madb group') is registered here.
+var cmdMadb = &cmdline.Command{
+	Children: []*cmdline.Command{cmdMadbName, cmdMadbGroup},
+	Name:     "madb",
+	Short:    "Run the same adb command over multiple devices or emulators",
+	Long: `
+Madb is a command-line tool that makes it easy to run adb and android
+commands simultaneously on multiple attached Android devices and/or
+emulators.
+`,
+}
+
+func main() {
+	cmdline.Main(cmdMadb)
+}